@@ -21,17 +21,54 @@ import (
 	"maunium.net/go/gomuks/matrix/rooms"
 )
 
+// MatrixContainer manages one or more logged-in Matrix accounts and the
+// rooms associated with them. Most methods take an accountID (the MXID of
+// the account to act as) as their first argument to disambiguate between
+// accounts.
+//
+// This accountID threading is interface-only scaffolding for a later
+// refactor: nothing in this tree yet stores, validates, or disambiguates on
+// it (no per-account room map, no change to how rooms.Room is keyed). A real
+// multi-account implementation still needs to land in a MatrixContainer
+// implementation before this is more than a shape for the API to grow into.
 type MatrixContainer interface {
-	Client() *gomatrix.Client
-	InitClient() error
+	// Accounts returns the MXIDs of all logged-in accounts.
+	Accounts() []string
+	Client(accountID string) *gomatrix.Client
+	InitClient(accountID string) error
 	Initialized() bool
+	// Login logs in a new account and adds it to the container.
 	Login(user, password string) error
+	// Logout logs out and removes the given account from the container.
+	Logout(accountID string) error
 	Start()
 	Stop()
-	SendMessage(roomID, msgtype, message string) (string, error)
-	SendTyping(roomID string, typing bool)
-	JoinRoom(roomID string) error
-	LeaveRoom(roomID string) error
-	GetHistory(roomID, prevBatch string, limit int) ([]gomatrix.Event, string, error)
-	GetRoom(roomID string) *rooms.Room
+	SendMessage(accountID, roomID, msgtype, message string) (string, error)
+	SendTyping(accountID, roomID string, typing bool)
+	JoinRoom(accountID, roomID string) error
+	LeaveRoom(accountID, roomID string) error
+	// GetHistory fetches up to limit events for roomID starting at prevBatch.
+	// Implementations should call rooms.Room.LoadOlderFromStore first and only
+	// hit the server for whatever it didn't find on disk.
+	GetHistory(accountID, roomID, prevBatch string, limit int) ([]gomatrix.Event, string, error)
+	// GetRoom returns the room with the given ID for the given account.
+	GetRoom(accountID, roomID string) *rooms.Room
+	// EnableEncryption marks a room as encrypted. Transparent encrypt/decrypt in
+	// SendMessage/GetHistory, the Olm device store, one-time-key claiming, m.room_key
+	// dispatch, and key-backup verification are not implemented yet; only the
+	// rooms.Room bookkeeping this method would rely on exists so far.
+	EnableEncryption(accountID, roomID string) error
+	LoadState() error
+	SaveState()
+	SendReadReceipt(accountID, roomID, eventID string) error
+	MarkFullyRead(accountID, roomID, eventID string) error
+	Invite(accountID, roomID, userID string) error
+	Kick(accountID, roomID, userID, reason string) error
+	Ban(accountID, roomID, userID, reason string) error
+	Unban(accountID, roomID, userID string) error
+	Redact(accountID, roomID, eventID, reason string) error
+	SetPowerLevel(accountID, roomID, userID string, level int) error
+	SetTag(accountID, roomID, tag string, order float64) error
+	RemoveTag(accountID, roomID, tag string) error
+	SetDirect(accountID, roomID string, isDirect bool) error
 }
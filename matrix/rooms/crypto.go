@@ -0,0 +1,71 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2018 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rooms
+
+import "time"
+
+// OutboundSession tracks the lifetime of a single outbound Megolm session
+// used to encrypt messages sent to a room.
+//
+// It only records the session's ID, age and message count. Nothing here
+// establishes Megolm sessions, encrypts messages, keeps an Olm device store
+// or claims one-time keys to share session keys with other devices.
+type OutboundSession struct {
+	ID string
+
+	CreatedAt    time.Time
+	MessageCount int
+}
+
+// Expired reports whether the session has exceeded the room's configured
+// rotation period, either in age or in number of messages encrypted.
+func (session *OutboundSession) Expired(room *Room) bool {
+	if room.EncryptionRotationPeriodMs > 0 {
+		maxAge := time.Duration(room.EncryptionRotationPeriodMs) * time.Millisecond
+		if time.Since(session.CreatedAt) >= maxAge {
+			return true
+		}
+	}
+	if room.EncryptionRotationPeriodMsgs > 0 && session.MessageCount >= room.EncryptionRotationPeriodMsgs {
+		return true
+	}
+	return false
+}
+
+// OutboundSession returns the room's current outbound Megolm session, or
+// nil if one hasn't been established yet or has been invalidated.
+func (room *Room) OutboundSession() *OutboundSession {
+	room.LockState()
+	defer room.UnlockState()
+	return room.outboundSession
+}
+
+// SetOutboundSession sets the room's current outbound Megolm session.
+func (room *Room) SetOutboundSession(session *OutboundSession) {
+	room.LockState()
+	defer room.UnlockState()
+	room.outboundSession = session
+}
+
+// InvalidateOutboundSession discards the room's current outbound Megolm
+// session, forcing a new one to be established before the next message
+// is sent.
+func (room *Room) InvalidateOutboundSession() {
+	room.LockState()
+	defer room.UnlockState()
+	room.outboundSession = nil
+}
@@ -0,0 +1,159 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2018 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rooms
+
+// powerLevels is the parsed content of a m.room.power_levels state event.
+type powerLevels struct {
+	UsersDefault  int
+	EventsDefault int
+	StateDefault  int
+	Users         map[string]int
+	Events        map[string]int
+	Invite        int
+	Kick          int
+	Ban           int
+	Redact        int
+}
+
+// defaultPowerLevels returns the power levels assumed when the room has no
+// m.room.power_levels event, per the Matrix spec.
+func defaultPowerLevels() *powerLevels {
+	return &powerLevels{
+		UsersDefault:  0,
+		EventsDefault: 0,
+		StateDefault:  50,
+		Invite:        0,
+		Kick:          50,
+		Ban:           50,
+		Redact:        50,
+	}
+}
+
+// parsePowerLevels builds the power level cache from the m.room.power_levels
+// state event, falling back to the spec defaults for anything that's unset.
+func (room *Room) parsePowerLevels() *powerLevels {
+	levels := defaultPowerLevels()
+	evt := room.GetStateEvent("m.room.power_levels", "")
+	if evt == nil {
+		return levels
+	}
+	if val, ok := evt.Content["users_default"].(float64); ok {
+		levels.UsersDefault = int(val)
+	}
+	if val, ok := evt.Content["events_default"].(float64); ok {
+		levels.EventsDefault = int(val)
+	}
+	if val, ok := evt.Content["state_default"].(float64); ok {
+		levels.StateDefault = int(val)
+	}
+	if val, ok := evt.Content["invite"].(float64); ok {
+		levels.Invite = int(val)
+	}
+	if val, ok := evt.Content["kick"].(float64); ok {
+		levels.Kick = int(val)
+	}
+	if val, ok := evt.Content["ban"].(float64); ok {
+		levels.Ban = int(val)
+	}
+	if val, ok := evt.Content["redact"].(float64); ok {
+		levels.Redact = int(val)
+	}
+	if users, ok := evt.Content["users"].(map[string]interface{}); ok {
+		levels.Users = make(map[string]int, len(users))
+		for userID, level := range users {
+			if val, ok := level.(float64); ok {
+				levels.Users[userID] = int(val)
+			}
+		}
+	}
+	if events, ok := evt.Content["events"].(map[string]interface{}); ok {
+		levels.Events = make(map[string]int, len(events))
+		for eventType, level := range events {
+			if val, ok := level.(float64); ok {
+				levels.Events[eventType] = int(val)
+			}
+		}
+	}
+	return levels
+}
+
+// getPowerLevels returns the room's power level cache, computing it first if
+// it's empty.
+func (room *Room) getPowerLevels() *powerLevels {
+	if room.powerLevelCache == nil {
+		room.powerLevelCache = room.parsePowerLevels()
+	}
+	return room.powerLevelCache
+}
+
+// GetPowerLevel returns the power level of the given user in this room.
+func (room *Room) GetPowerLevel(userID string) int {
+	levels := room.getPowerLevels()
+	if level, ok := levels.Users[userID]; ok {
+		return level
+	}
+	return levels.UsersDefault
+}
+
+// getEventPowerLevel returns the power level required to send the given
+// event type in this room.
+func (room *Room) getEventPowerLevel(eventType string, isState bool) int {
+	levels := room.getPowerLevels()
+	if level, ok := levels.Events[eventType]; ok {
+		return level
+	}
+	if isState {
+		return levels.StateDefault
+	}
+	return levels.EventsDefault
+}
+
+// CanSend returns whether the given user has a high enough power level to
+// send the given event type as a non-state message event.
+func (room *Room) CanSend(userID, eventType string) bool {
+	return room.GetPowerLevel(userID) >= room.getEventPowerLevel(eventType, false)
+}
+
+// CanSendState returns whether the given user has a high enough power level
+// to send the given event type as a state event.
+func (room *Room) CanSendState(userID, eventType string) bool {
+	return room.GetPowerLevel(userID) >= room.getEventPowerLevel(eventType, true)
+}
+
+// CanRedact returns whether the given user is allowed to redact other users'
+// events in this room.
+func (room *Room) CanRedact(userID string) bool {
+	return room.GetPowerLevel(userID) >= room.getPowerLevels().Redact
+}
+
+// CanKick returns whether the given user is allowed to kick other members
+// out of this room.
+func (room *Room) CanKick(userID string) bool {
+	return room.GetPowerLevel(userID) >= room.getPowerLevels().Kick
+}
+
+// CanBan returns whether the given user is allowed to ban other members
+// from this room.
+func (room *Room) CanBan(userID string) bool {
+	return room.GetPowerLevel(userID) >= room.getPowerLevels().Ban
+}
+
+// CanInvite returns whether the given user is allowed to invite other users
+// to this room.
+func (room *Room) CanInvite(userID string) bool {
+	return room.GetPowerLevel(userID) >= room.getPowerLevels().Invite
+}
@@ -0,0 +1,80 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2018 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rooms
+
+import (
+	"maunium.net/go/gomatrix"
+)
+
+// ReadReceipt is another user's read position in a room, as received from
+// a m.receipt ephemeral event.
+type ReadReceipt struct {
+	EventID   string
+	Timestamp int64
+}
+
+// UpdateReceipts updates the room's read receipt cache from a m.receipt
+// ephemeral event. The event's content maps event IDs to receipt types to
+// user IDs to receipt metadata, e.g.:
+//
+//	{"$event": {"m.read": {"@user:example.com": {"ts": 1234}}}}
+func (room *Room) UpdateReceipts(event *gomatrix.Event) {
+	if room.ReadReceipts == nil {
+		room.ReadReceipts = make(map[string]ReadReceipt)
+	}
+	for eventID, receiptTypes := range event.Content {
+		receipts, ok := receiptTypes.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		readReceipts, ok := receipts["m.read"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for userID, data := range readReceipts {
+			receiptData, ok := data.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ts, _ := receiptData["ts"].(float64)
+			room.ReadReceipts[userID] = ReadReceipt{
+				EventID:   eventID,
+				Timestamp: int64(ts),
+			}
+		}
+	}
+}
+
+// UpdateTyping updates the list of users currently typing in the room, as
+// received from a m.typing ephemeral event.
+func (room *Room) UpdateTyping(userIDs []string) {
+	room.TypingUsers = userIDs
+}
+
+// GetReadersOf returns the members whose latest read receipt points at the
+// given event ID.
+func (room *Room) GetReadersOf(eventID string) []*Member {
+	var readers []*Member
+	for userID, receipt := range room.ReadReceipts {
+		if receipt.EventID == eventID {
+			if member := room.GetMember(userID); member != nil {
+				readers = append(readers, member)
+			}
+		}
+	}
+	return readers
+}
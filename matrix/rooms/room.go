@@ -30,9 +30,16 @@ type Room struct {
 	// The first batch of events that has been fetched for this room.
 	// Used for fetching additional history.
 	PrevBatch string
+	// The sync token to resume syncing this room from. Persisted so gomuks
+	// doesn't need a full initial sync after a restart.
+	NextBatch string
 	// The MXID of the user whose session this room was created for.
 	SessionUserID string
 
+	// Historical events fetched for this room, oldest first. Events older
+	// than what's held here can be loaded from the store via LoadOlderFromStore.
+	Timeline []gomatrix.Event
+
 	// The number of unread messages that were notified about.
 	UnreadMessages int
 	// Whether or not any of the unread messages were highlights.
@@ -42,6 +49,29 @@ type Room struct {
 	// a notificationless message like bot notices.
 	HasNewMessages bool
 
+	// MXID -> other user's read receipt, from m.receipt ephemeral events.
+	ReadReceipts map[string]ReadReceipt
+	// The MXIDs of the users currently typing in this room, from m.typing ephemeral events.
+	TypingUsers []string
+
+	// Tag -> order, from the m.tag room account data event.
+	Tags map[string]float64
+	// Whether or not this room is a direct message, from the m.direct global account data event.
+	IsDirect bool
+
+	// Whether or not this room is encrypted, as set by the m.room.encryption state event.
+	Encrypted bool
+	// The megolm algorithm used to encrypt events in this room, e.g. m.megolm.v1.aes-sha2.
+	EncryptionAlgorithm string
+	// The maximum age an outbound Megolm session may reach before it must be rotated.
+	EncryptionRotationPeriodMs int64
+	// The maximum number of messages an outbound Megolm session may encrypt before it must be rotated.
+	EncryptionRotationPeriodMsgs int
+
+	// The current outbound Megolm session used to encrypt messages sent to this room.
+	// Invalidated whenever the room membership or encryption settings change.
+	outboundSession *OutboundSession
+
 	// MXID -> Member cache calculated from membership events.
 	memberCache map[string]*Member
 	// The first non-SessionUserID member in the room. Calculated at
@@ -52,10 +82,32 @@ type Room struct {
 	nameCache string
 	// The topic of the room. Directly fetched from the m.room.topic state event.
 	topicCache string
+	// The power levels of this room. Calculated from the m.room.power_levels state event.
+	powerLevelCache *powerLevels
 
 	// fetchHistoryLock is used to make sure multiple goroutines don't fetch
 	// history for this room at the same time.
 	fetchHistoryLock *sync.Mutex
+
+	// stateMu guards State against concurrent access between the sync goroutine
+	// updating it and a debounced Store.Save encoding it on its own goroutine.
+	stateMu sync.Mutex
+
+	// store is where this room's state is persisted to, if any.
+	store *Store
+}
+
+// LockState locks the mutex guarding the Room fields Store.Save reads
+// (State, Timeline, PrevBatch, NextBatch, UnreadMessages, Highlighted)
+// and outboundSession. Any code that reads or writes one of those fields
+// outside of UpdateState must hold this lock for the duration.
+func (room *Room) LockState() {
+	room.stateMu.Lock()
+}
+
+// UnlockState unlocks the mutex guarding Room's persisted fields.
+func (room *Room) UnlockState() {
+	room.stateMu.Unlock()
 }
 
 // LockHistory locks the history fetching mutex.
@@ -77,14 +129,28 @@ func (room *Room) UnlockHistory() {
 
 // MarkRead clears the new message statuses on this room.
 func (room *Room) MarkRead() {
+	room.LockState()
 	room.UnreadMessages = 0
 	room.Highlighted = false
 	room.HasNewMessages = false
+	room.UnlockState()
+	room.scheduleSave()
+}
+
+// scheduleSave debounces a write of the room's state to its store, if one
+// is attached.
+func (room *Room) scheduleSave() {
+	if room.store != nil {
+		room.store.SaveDebounced(room)
+	}
 }
 
 // UpdateState updates the room's current state with the given Event. This will clobber events based
 // on the type/state_key combination.
 func (room *Room) UpdateState(event *gomatrix.Event) {
+	room.stateMu.Lock()
+	defer room.stateMu.Unlock()
+
 	_, exists := room.State[event.Type]
 	if !exists {
 		room.State[event.Type] = make(map[string]*gomatrix.Event)
@@ -93,6 +159,7 @@ func (room *Room) UpdateState(event *gomatrix.Event) {
 	case "m.room.member":
 		room.memberCache = nil
 		room.firstMemberCache = ""
+		room.outboundSession = nil
 		fallthrough
 	case "m.room.name":
 		fallthrough
@@ -102,8 +169,14 @@ func (room *Room) UpdateState(event *gomatrix.Event) {
 		room.nameCache = ""
 	case "m.room.topic":
 		room.topicCache = ""
+	case "m.room.encryption":
+		room.updateEncryptionInfo(event)
+		room.outboundSession = nil
+	case "m.room.power_levels":
+		room.powerLevelCache = nil
 	}
 	room.State[event.Type][*event.StateKey] = event
+	room.scheduleSave()
 }
 
 // GetStateEvent returns the state event for the given type/state_key combo, or nil.
@@ -119,6 +192,30 @@ func (room *Room) GetStateEvents(eventType string) map[string]*gomatrix.Event {
 	return stateEventMap
 }
 
+// defaultRotationPeriodMs and defaultRotationPeriodMsgs are the values the
+// spec says clients must assume for m.room.encryption when the event omits
+// rotation_period_ms/rotation_period_msgs. Leaving these at zero would be
+// read by OutboundSession.Expired as "never rotate", silently defeating
+// forward secrecy for any room that relies on the implicit defaults.
+const (
+	defaultRotationPeriodMs   = 604800000
+	defaultRotationPeriodMsgs = 100
+)
+
+// updateEncryptionInfo updates the room's encryption settings from a m.room.encryption state event.
+func (room *Room) updateEncryptionInfo(event *gomatrix.Event) {
+	room.Encrypted = true
+	room.EncryptionAlgorithm, _ = event.Content["algorithm"].(string)
+	room.EncryptionRotationPeriodMs = defaultRotationPeriodMs
+	if rotationMs, ok := event.Content["rotation_period_ms"].(float64); ok {
+		room.EncryptionRotationPeriodMs = int64(rotationMs)
+	}
+	room.EncryptionRotationPeriodMsgs = defaultRotationPeriodMsgs
+	if rotationMsgs, ok := event.Content["rotation_period_msgs"].(float64); ok {
+		room.EncryptionRotationPeriodMsgs = int(rotationMsgs)
+	}
+}
+
 // GetTopic returns the topic of the room.
 func (room *Room) GetTopic() string {
 	if len(room.topicCache) == 0 {
@@ -0,0 +1,208 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2018 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rooms
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"maunium.net/go/gomatrix"
+)
+
+// storeSchemaVersion is bumped whenever roomState's on-disk layout changes
+// in a way older gomuks versions can't read. Load decodes the file and then
+// rejects it if its version differs, instead of building a room from it.
+const storeSchemaVersion = 1
+
+// saveDebounceInterval is how long a room's state is allowed to stay dirty
+// before it's flushed to disk, so that a burst of incoming events only
+// results in a single write.
+const saveDebounceInterval = 2 * time.Second
+
+// roomState is the gob-serializable subset of Room that gets persisted.
+type roomState struct {
+	SchemaVersion  int
+	State          map[string]map[string]*gomatrix.Event
+	PrevBatch      string
+	UnreadMessages int
+	Highlighted    bool
+	// NextBatch is the sync token to resume syncing this room from, so gomuks
+	// doesn't need a full initial sync after a restart.
+	NextBatch string
+	// Timeline holds the historical events fetched for this room, oldest
+	// first, so scrollback can be served from disk instead of re-fetched
+	// from the server.
+	Timeline []gomatrix.Event
+}
+
+// errSchemaMismatch is returned by Load when a room file was written by an
+// incompatible version of the store.
+var errSchemaMismatch = errors.New("room state schema version mismatch")
+
+// errTimelineDiverged is returned by LoadOlderFromStore when the stored
+// timeline's overlap with the in-memory one doesn't match, so splicing them
+// together can't be trusted to produce correctly ordered, gap-free history.
+var errTimelineDiverged = errors.New("stored timeline has diverged from the in-memory one")
+
+// Event.Content holds arbitrary JSON decoded into interface{} values,
+// so gob needs every concrete type that can end up in there registered
+// up front or encoding fails with "gob: type not registered for interface"
+// as soon as a room has any non-trivial state event (power levels, member
+// third_party_invite, etc).
+func init() {
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+	gob.Register(float64(0))
+	gob.Register("")
+	gob.Register(false)
+}
+
+// Store persists Room state to disk as gob-encoded files, so gomuks can
+// resume without a full initial sync and can load scrollback that doesn't
+// fit in memory anymore.
+type Store struct {
+	Directory string
+
+	// OnSaveError, if set, is called whenever a debounced save fails. Save
+	// itself returns its error directly to the caller; SaveDebounced runs
+	// the save on a timer goroutine with no caller to return an error to,
+	// so this is the only way to observe those failures.
+	OnSaveError func(roomID string, err error)
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// NewStore creates a Store that reads and writes room files in directory.
+func NewStore(directory string) *Store {
+	return &Store{
+		Directory: directory,
+		timers:    make(map[string]*time.Timer),
+	}
+}
+
+func (store *Store) path(roomID string) string {
+	return filepath.Join(store.Directory, url.QueryEscape(roomID)+".gob")
+}
+
+// Save serializes room and atomically replaces its file on disk.
+func (store *Store) Save(room *Room) error {
+	room.LockState()
+	state := roomState{
+		SchemaVersion:  storeSchemaVersion,
+		State:          room.State,
+		PrevBatch:      room.PrevBatch,
+		NextBatch:      room.NextBatch,
+		UnreadMessages: room.UnreadMessages,
+		Highlighted:    room.Highlighted,
+		Timeline:       room.Timeline,
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(&state)
+	room.UnlockState()
+	if err != nil {
+		return err
+	}
+	path := store.path(room.ID)
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// SaveDebounced schedules room to be saved after saveDebounceInterval,
+// coalescing rapid-fire state updates into a single write. Calling it
+// again before the timer fires restarts the wait.
+func (store *Store) SaveDebounced(room *Room) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if timer, ok := store.timers[room.ID]; ok {
+		timer.Stop()
+	}
+	store.timers[room.ID] = time.AfterFunc(saveDebounceInterval, func() {
+		if err := store.Save(room); err != nil && store.OnSaveError != nil {
+			store.OnSaveError(room.ID, err)
+		}
+	})
+}
+
+// Load deserializes the room with the given ID from disk. It returns
+// errSchemaMismatch, without partially populating the room, if the file was
+// written by an incompatible version of the store.
+func (store *Store) Load(roomID, owner string) (*Room, error) {
+	data, err := os.ReadFile(store.path(roomID))
+	if err != nil {
+		return nil, err
+	}
+	var state roomState
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&state); err != nil {
+		return nil, err
+	}
+	if state.SchemaVersion != storeSchemaVersion {
+		return nil, errSchemaMismatch
+	}
+	room := NewRoom(roomID, owner)
+	room.State = state.State
+	room.PrevBatch = state.PrevBatch
+	room.NextBatch = state.NextBatch
+	room.UnreadMessages = state.UnreadMessages
+	room.Highlighted = state.Highlighted
+	room.Timeline = state.Timeline
+	room.SetStore(store)
+	return room, nil
+}
+
+// LoadOlderFromStore loads historical events from the store that are older
+// than what room currently holds in Timeline, prepending them. This lets
+// GetHistory serve scrollback from disk before falling back to the server.
+// It returns the number of events loaded.
+func (room *Room) LoadOlderFromStore() (int, error) {
+	if room.store == nil {
+		return 0, nil
+	}
+	stored, err := room.store.Load(room.ID, room.SessionUserID)
+	if err != nil {
+		return 0, err
+	}
+
+	room.LockState()
+	defer room.UnlockState()
+
+	if len(stored.Timeline) <= len(room.Timeline) {
+		return 0, nil
+	}
+	boundary := len(stored.Timeline) - len(room.Timeline)
+	if len(room.Timeline) > 0 && stored.Timeline[boundary].ID != room.Timeline[0].ID {
+		return 0, errTimelineDiverged
+	}
+	older := append([]gomatrix.Event(nil), stored.Timeline[:boundary]...)
+	room.Timeline = append(older, room.Timeline...)
+	return len(older), nil
+}
+
+// SetStore attaches the store that room's state changes should be persisted
+// to. Passing nil disables persistence.
+func (room *Room) SetStore(store *Store) {
+	room.store = store
+}
@@ -0,0 +1,44 @@
+// gomuks - A terminal Matrix client written in Go.
+// Copyright (C) 2018 Tulir Asokan
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package rooms
+
+// UpdateTags replaces the room's tag cache from the content of a m.tag
+// account data event, e.g. {"tags": {"m.favourite": {"order": 0.5}}}.
+func (room *Room) UpdateTags(content map[string]interface{}) {
+	tags, _ := content["tags"].(map[string]interface{})
+	room.Tags = make(map[string]float64, len(tags))
+	for tag, data := range tags {
+		tagData, ok := data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		order, _ := tagData["order"].(float64)
+		room.Tags[tag] = order
+	}
+}
+
+// HasTag returns whether the room has the given tag.
+func (room *Room) HasTag(tag string) bool {
+	_, ok := room.Tags[tag]
+	return ok
+}
+
+// SetDirect sets whether this room is a direct message with another user,
+// as derived from the m.direct global account data event.
+func (room *Room) SetDirect(isDirect bool) {
+	room.IsDirect = isDirect
+}